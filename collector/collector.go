@@ -24,6 +24,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector/utils"
 )
 
 // Namespace defines the common Namespace to be used by all metrics.
@@ -149,6 +150,7 @@ func (n NodeCollector) Collect(ch chan<- prometheus.Metric) {
 	wg.Add(len(n.Collectors))
 	for name, c := range n.Collectors {
 		go func(name string, c Collector) {
+			defer utils.HandlePanic()
 			execute(name, c, ch, n.logger)
 			wg.Done()
 		}(name, c)
@@ -17,6 +17,14 @@ const (
 	DURATION     = "DURATION"
 )
 
+const (
+	LocaleEN = "en"
+	LocaleZH = "zh"
+)
+
+// HelpLocale the locale of exported metric help strings
+var HelpLocale = LocaleEN
+
 var ColumnUsage = map[string]bool{
 	DISCARD:      true,
 	LABEL:        true,
@@ -33,12 +41,21 @@ type Column struct {
 	Histogram      bool                 `yaml:"-"` // Should metric be treated as a histogram?
 	Name           string               `yaml:"name"`
 	Desc           string               `yaml:"description,omitempty"`
+	DescZh         string               `yaml:"description_zh,omitempty"` // chinese help, exported with --metrics.help-locale=zh
 	Usage          string               `yaml:"usage,omitempty"`
 	Rename         string               `yaml:"rename,omitempty"`
 	PrometheusDesc *prometheus.Desc     `yaml:"-"`
 	PrometheusType prometheus.ValueType `yaml:"-"`
 }
 
+// Help return the help string of the column in HelpLocale, falling back to the english description
+func (c *Column) Help() string {
+	if HelpLocale == LocaleZH && c.DescZh != "" {
+		return c.DescZh
+	}
+	return c.Desc
+}
+
 func (c *Column) String() string {
 	return fmt.Sprintf("%-8s %-30s %s", c.Usage, c.Name, c.Desc)
 }
@@ -0,0 +1,21 @@
+package config
+
+import "testing"
+
+func TestColumnHelp(t *testing.T) {
+	defer func(locale string) { HelpLocale = locale }(HelpLocale)
+	translated := &Column{Desc: "Number of deadlocks", DescZh: "死锁数"}
+	untranslated := &Column{Desc: "Number of temp files"}
+
+	HelpLocale = LocaleEN
+	if got := translated.Help(); got != "Number of deadlocks" {
+		t.Errorf("want english help, got %q", got)
+	}
+	HelpLocale = LocaleZH
+	if got := translated.Help(); got != "死锁数" {
+		t.Errorf("want chinese help, got %q", got)
+	}
+	if got := untranslated.Help(); got != "Number of temp files" {
+		t.Errorf("want english fallback, got %q", got)
+	}
+}
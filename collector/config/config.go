@@ -20,6 +20,12 @@ var MetricMap = make(map[string]*QueryInstance)
 // var DBHandler *sql.DB
 var DBMap = make(map[string]GBInfo)
 
+// DisableCache force every scrape to query the database, ignoring enableCache
+var DisableCache bool
+
+// Namespace prefix of the metrics the exporter reports about itself, set by --namespace
+var Namespace = "pg"
+
 func GetDBConnection(address string, port int) *sql.DB {
 	return DBMap[fmt.Sprintf("%s:%d", address, port)].Connection
 }
@@ -35,7 +41,7 @@ func InitConfig(configPath string) error {
 		slog.Error("Error loading default configs.", slog.Any("error", err))
 		return err
 	}
-	return nil
+	return CheckDuplicateMetrics(MetricMap, DuplicateMetrics)
 }
 
 // LoadConfig 读取配置文件
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DefaultExcludeDatabases databases excluded when auto-discovery is on and no exclude list is given
+const DefaultExcludeDatabases = "template0,template1"
+
+// DBFilter decide which databases are scraped, allow all by default
+var DBFilter = &DatabaseFilter{}
+
+var (
+	databasesMtx  sync.Mutex
+	AutoDiscovery bool                   // resolve the database list from pg_database
+	databases     []string               // databases whose rows pass include/exclude
+	databaseConns = map[string]*sql.DB{} // connections to resolved databases other than the target one
+)
+
+// DatabaseFilter include/exclude databases by name. Patterns are regular
+// expressions matching the whole name, so plain names still match exactly.
+type DatabaseFilter struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// NewDatabaseFilter build a DatabaseFilter from comma separated include and exclude patterns
+func NewDatabaseFilter(include, exclude string) (*DatabaseFilter, error) {
+	f := &DatabaseFilter{}
+	var err error
+	if f.Include, f.include, err = compilePatterns(include); err != nil {
+		return nil, fmt.Errorf("invalid include databases: %w", err)
+	}
+	if f.Exclude, f.exclude, err = compilePatterns(exclude); err != nil {
+		return nil, fmt.Errorf("invalid exclude databases: %w", err)
+	}
+	return f, nil
+}
+
+func compilePatterns(s string) ([]string, []*regexp.Regexp, error) {
+	var patterns []string
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + p + ")$")
+		if err != nil {
+			return nil, nil, err
+		}
+		patterns = append(patterns, p)
+		res = append(res, re)
+	}
+	return patterns, res, nil
+}
+
+// Allowed check whether datname is scraped: it must not match any exclude
+// pattern, and must match an include pattern if any is given
+func (f *DatabaseFilter) Allowed(datname string) bool {
+	for _, re := range f.exclude {
+		if re.MatchString(datname) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, re := range f.include {
+		if re.MatchString(datname) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveDatabases refresh the list of databases whose rows pass DBFilter: every connectable database
+// when AutoDiscovery is on, the target database otherwise. Non public queries are run on each of them,
+// connections to databases no longer resolved are closed.
+func ResolveDatabases(db *sql.DB) error {
+	candidates := []string{MonitDB.Database}
+	if AutoDiscovery {
+		rows, err := db.Query("SELECT datname FROM pg_database WHERE datallowconn ORDER BY datname")
+		if err != nil {
+			return fmt.Errorf("discover databases: %w", err)
+		}
+		defer rows.Close()
+		candidates = candidates[:0]
+		for rows.Next() {
+			var datname string
+			if err := rows.Scan(&datname); err != nil {
+				return fmt.Errorf("discover databases: %w", err)
+			}
+			candidates = append(candidates, datname)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("discover databases: %w", err)
+		}
+	}
+	resolved := make([]string, 0, len(candidates))
+	for _, datname := range candidates {
+		if DBFilter.Allowed(datname) {
+			resolved = append(resolved, datname)
+		}
+	}
+	databasesMtx.Lock()
+	defer databasesMtx.Unlock()
+	databases = resolved
+	for datname, conn := range databaseConns {
+		if !contains(resolved, datname) {
+			_ = conn.Close()
+			delete(databaseConns, datname)
+		}
+	}
+	return nil
+}
+
+// DatabaseConnection return the connection to datname, opened on first use with the credentials
+// of the target database
+func DatabaseConnection(datname string) (*sql.DB, error) {
+	if datname == MonitDB.Database {
+		return GetDBConnection(MonitDB.Address, MonitDB.Port), nil
+	}
+	databasesMtx.Lock()
+	defer databasesMtx.Unlock()
+	if conn, ok := databaseConns[datname]; ok {
+		return conn, nil
+	}
+	target := *MonitDB
+	target.Database = datname
+	conn, err := sql.Open("postgres", target.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("connect to database %s: %w", datname, err)
+	}
+	// only non public queries use it, one connection per database bounds what a server with many databases opens
+	conn.SetMaxOpenConns(1)
+	databaseConns[datname] = conn
+	return conn, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolvedDatabases return the effective database list
+func ResolvedDatabases() []string {
+	databasesMtx.Lock()
+	defer databasesMtx.Unlock()
+	return append([]string{}, databases...)
+}
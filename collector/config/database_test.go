@@ -0,0 +1,56 @@
+package config
+
+import "testing"
+
+func TestDatabaseFilter(t *testing.T) {
+	f, err := NewDatabaseFilter("", "template0,template1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for datname, want := range map[string]bool{"template0": false, "template1": false, "template10": true, "omm": true} {
+		if got := f.Allowed(datname); got != want {
+			t.Errorf("exclude names: %s allowed=%v, want %v", datname, got, want)
+		}
+	}
+
+	f, err = NewDatabaseFilter("app_.*, omm", "template\\d+, app_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for datname, want := range map[string]bool{"app_order": true, "app_test": false, "omm": true, "my_app_order": false, "postgres": false, "template1": false} {
+		if got := f.Allowed(datname); got != want {
+			t.Errorf("regex: %s allowed=%v, want %v", datname, got, want)
+		}
+	}
+
+	if _, err := NewDatabaseFilter("app_(", ""); err == nil {
+		t.Error("want error for malformed pattern")
+	}
+}
+
+func TestDBNameLabel(t *testing.T) {
+	tests := []struct {
+		labels   []string
+		explicit string
+		want     string
+	}{
+		{labels: []string{"nspname", "datname"}, want: "datname"},
+		{labels: []string{"slot_name", "database"}, want: "database"},
+		{labels: []string{"schema_name", "db_name"}, want: "db_name"},
+		{labels: []string{"database", "datname"}, want: "datname"},
+		{labels: []string{"database"}, explicit: "datname", want: "datname"},
+		{labels: []string{"relname"}, want: ""},
+	}
+	for _, tt := range tests {
+		q := &QueryInstance{Name: "test", DBNameLabel: tt.explicit}
+		for _, label := range tt.labels {
+			q.Metrics = append(q.Metrics, &Column{Name: label, Usage: LABEL})
+		}
+		if err := q.Check(); err != nil {
+			t.Fatal(err)
+		}
+		if q.DBNameLabel != tt.want {
+			t.Errorf("labels %v: DBNameLabel = %q, want %q", tt.labels, q.DBNameLabel, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/slog"
+)
+
+const (
+	DuplicateReject = "reject" // fail loading the config
+	DuplicateMerge  = "merge"  // keep the metric of the first query only
+)
+
+// DuplicateMetrics what to do when several queries emit the same metric family with identical labels
+var DuplicateMetrics = DuplicateReject
+
+// CheckDuplicateMetrics detect metric families emitted by more than one query with identical
+// label sets, which Prometheus would reject at scrape time. Disabled queries are ignored, the
+// others are ranked by priority then name: with DuplicateMerge the later ones stop emitting
+// the family, with DuplicateReject an error listing every duplicate is returned.
+func CheckDuplicateMetrics(queries map[string]*QueryInstance, policy string) error {
+	names := make([]string, 0, len(queries))
+	for name, q := range queries {
+		if q.Status == statusDisable {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		qi, qj := queries[names[i]], queries[names[j]]
+		if qi.Priority != qj.Priority {
+			return qi.Priority < qj.Priority
+		}
+		return names[i] < names[j]
+	})
+
+	owners := make(map[string]string) // metric signature -> query name
+	var duplicates []string
+	for _, name := range names {
+		q := queries[name]
+		// GetColumn builds the exported Desc from the raw label names, Rename is not applied
+		labels := append([]string{}, q.LabelNames...)
+		sort.Strings(labels)
+		var merged bool
+		for _, metricName := range q.MetricNames {
+			column := q.Columns[metricName]
+			family, ok := q.metricFamily(column)
+			if !ok {
+				continue
+			}
+			signature := fmt.Sprintf("%s{%s}", family, strings.Join(labels, ","))
+			owner, exists := owners[signature]
+			if !exists {
+				owners[signature] = name
+				continue
+			}
+			msg := fmt.Sprintf("metric %s is emitted by query %s (%s) and query %s (%s)",
+				signature, owner, queries[owner].Path, name, q.Path)
+			if policy != DuplicateMerge {
+				duplicates = append(duplicates, msg)
+				continue
+			}
+			slog.Warn("duplicate metric merged, keeping the first query", slog.String("duplicate", msg))
+			column.Usage = DISCARD
+			merged = true
+		}
+		if merged {
+			if err := q.Check(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("duplicate metrics, remove them or use --config.duplicate-metrics=merge:\n%s", strings.Join(duplicates, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const duplicateConfig = `
+pg_lock:
+  name: pg_lock
+  query:
+    - name: pg_lock
+      sql: select datname, mode, count(*) as count from pg_locks group by 1, 2
+  metrics:
+    - name: datname
+      usage: LABEL
+    - name: mode
+      usage: LABEL
+    - name: count
+      usage: GAUGE
+pg_lock_copy:
+  name: pg_lock
+  priority: 200
+  query:
+    - name: pg_lock
+      sql: select mode, datname, count(*) as count, 1 as waiting from pg_locks group by 1, 2
+  metrics:
+    - name: mode
+      usage: LABEL
+    - name: datname
+      usage: LABEL
+    - name: count
+      usage: GAUGE
+    - name: waiting
+      usage: GAUGE
+pg_lock_disabled:
+  name: pg_lock
+  status: disable
+  query:
+    - name: pg_lock
+      sql: select datname, mode, count(*) as count from pg_locks group by 1, 2
+  metrics:
+    - name: datname
+      usage: LABEL
+    - name: mode
+      usage: LABEL
+    - name: count
+      usage: GAUGE
+pg_lock_renamed:
+  name: pg_lock
+  query:
+    - name: pg_lock
+      sql: select mode as lock_mode, count(*) as count from pg_locks group by 1
+  metrics:
+    - name: lock_mode
+      usage: LABEL
+      rename: mode
+    - name: count
+      usage: GAUGE
+pg_lock_by_mode:
+  name: pg_lock
+  query:
+    - name: pg_lock
+      sql: select mode, count(*) as count from pg_locks group by 1
+  metrics:
+    - name: mode
+      usage: LABEL
+    - name: count
+      usage: GAUGE
+`
+
+func TestCheckDuplicateMetrics(t *testing.T) {
+	queries, err := ParseConfig([]byte(duplicateConfig), "dup.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = CheckDuplicateMetrics(queries, DuplicateReject)
+	if err == nil || !strings.Contains(err.Error(), "metric pg_lock_count{datname,mode} is emitted by query pg_lock (dup.yaml) and query pg_lock_copy (dup.yaml)") {
+		t.Fatalf("want duplicate pg_lock_count rejected, got %v", err)
+	}
+	if strings.Contains(err.Error(), "pg_lock_by_mode") || strings.Contains(err.Error(), "pg_lock_renamed") {
+		t.Errorf("different exported label sets are not duplicates: %v", err)
+	}
+	if strings.Contains(err.Error(), "pg_lock_disabled") {
+		t.Errorf("disabled queries are not duplicates: %v", err)
+	}
+
+	queries, _ = ParseConfig([]byte(duplicateConfig), "dup.yaml")
+	if err := CheckDuplicateMetrics(queries, DuplicateMerge); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(queries["pg_lock_copy"].MetricFamilies(), ","); got != "pg_lock_waiting" {
+		t.Errorf("want duplicate dropped from the later query, got %s", got)
+	}
+	if got := strings.Join(queries["pg_lock"].MetricFamilies(), ","); got != "pg_lock_count" {
+		t.Errorf("want first query untouched, got %s", got)
+	}
+}
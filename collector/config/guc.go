@@ -0,0 +1,86 @@
+package config
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/slog"
+)
+
+// GUCSettings current value of the GUCs required by queries, probed at startup
+var GUCSettings = make(map[string]string)
+
+// GUCRequirement a server setting (GUC) a query depends on to return data
+type GUCRequirement struct {
+	Name           string   `yaml:"guc"`                      // GUC name, e.g. enable_resource_track
+	DisabledValues []string `yaml:"disabledValues,omitempty"` // settings meaning the feature is off, "off" by default
+}
+
+// Satisfied check whether setting turn on the feature required by the query
+func (g *GUCRequirement) Satisfied(setting string) bool {
+	disabled := g.DisabledValues
+	if len(disabled) == 0 {
+		disabled = []string{"off"}
+	}
+	for _, v := range disabled {
+		if strings.EqualFold(strings.TrimSpace(setting), v) {
+			return false
+		}
+	}
+	return true
+}
+
+// UnmetRequirement return the first GUC requirement turned off on the server and its setting.
+// GUCs that were not probed (e.g. not existing in this version) are considered satisfied.
+func (q *QueryInstance) UnmetRequirement() (*GUCRequirement, string) {
+	for _, req := range q.Requires {
+		setting, ok := GUCSettings[req.Name]
+		if !ok {
+			continue
+		}
+		if !req.Satisfied(setting) {
+			return req, setting
+		}
+	}
+	return nil, ""
+}
+
+// ProbeGUCs read the GUCs required by queries from pg_settings into GUCSettings
+func ProbeGUCs(db *sql.DB, queries map[string]*QueryInstance) error {
+	required := make(map[string]bool)
+	for _, q := range queries {
+		for _, req := range q.Requires {
+			required[req.Name] = true
+		}
+	}
+	if len(required) == 0 {
+		return nil
+	}
+	rows, err := db.Query("SELECT name, setting FROM pg_settings")
+	if err != nil {
+		return fmt.Errorf("probe GUCs: %w", err)
+	}
+	defer rows.Close()
+	settings := make(map[string]string, len(required))
+	for rows.Next() {
+		var name, setting string
+		if err := rows.Scan(&name, &setting); err != nil {
+			return fmt.Errorf("probe GUCs: %w", err)
+		}
+		if required[name] {
+			settings[name] = setting
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("probe GUCs: %w", err)
+	}
+	GUCSettings = settings
+	for name, q := range queries {
+		if req, setting := q.UnmetRequirement(); req != nil {
+			slog.Warn("query disabled, required GUC is off", slog.String("query", name),
+				slog.String("guc", req.Name), slog.String("setting", setting))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package config
+
+import "testing"
+
+func TestUnmetRequirement(t *testing.T) {
+	defer func(s map[string]string) { GUCSettings = s }(GUCSettings)
+	q := &QueryInstance{Requires: []*GUCRequirement{
+		{Name: "enable_resource_track"},
+		{Name: "track_stmt_stat_level", DisabledValues: []string{"OFF,OFF"}},
+	}}
+
+	GUCSettings = map[string]string{}
+	if req, _ := q.UnmetRequirement(); req != nil {
+		t.Errorf("unprobed GUCs should be satisfied, got %s", req.Name)
+	}
+
+	GUCSettings = map[string]string{"enable_resource_track": "on", "track_stmt_stat_level": "OFF,L0"}
+	if req, _ := q.UnmetRequirement(); req != nil {
+		t.Errorf("want all requirements met, got %s", req.Name)
+	}
+
+	GUCSettings = map[string]string{"enable_resource_track": "on", "track_stmt_stat_level": "off,off"}
+	req, setting := q.UnmetRequirement()
+	if req == nil || req.Name != "track_stmt_stat_level" || setting != "off,off" {
+		t.Errorf("want track_stmt_stat_level unmet, got %v %q", req, setting)
+	}
+
+	GUCSettings = map[string]string{"enable_resource_track": "OFF"}
+	if req, _ := q.UnmetRequirement(); req == nil || req.Name != "enable_resource_track" {
+		t.Errorf("want enable_resource_track unmet, got %v", req)
+	}
+}
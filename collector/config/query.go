@@ -55,16 +55,17 @@ type QueryInstance struct {
 	Metrics     []*Column          `yaml:"metrics,omitempty"` // metric definition list
 	Status      string             `yaml:"status,omitempty"`  // enable/disable status. For the entire collection of indicators 针对整个采集指标
 	EnableCache string             `yaml:"enableCache,omitempty"`
-	TTL         float64            `yaml:"ttl,omitempty"`      // caching ttl in seconds
-	Priority    int                `yaml:"priority,omitempty"` // 权重,暂时不用
-	Timeout     float64            `yaml:"timeout,omitempty"`  // query execution timeout in seconds
-	Path        string             `yaml:"-"`                  // where am I from ?
-	Columns     map[string]*Column `yaml:"-"`                  // column map
-	ColumnNames []string           `yaml:"-"`                  // column names in origin orders
-	LabelNames  []string           `yaml:"-"`                  // column (name) that used as label, sequences matters
-	MetricNames []string           `yaml:"-"`                  // column (name) that used as metric
-	Public      bool               `yaml:"public,omitempty"`   // autoDiscover下公用指标,只采集一次
-	DBNameLabel string
+	TTL         float64            `yaml:"ttl,omitempty"`         // caching ttl in seconds
+	Priority    int                `yaml:"priority,omitempty"`    // 权重,暂时不用
+	Timeout     float64            `yaml:"timeout,omitempty"`     // query execution timeout in seconds
+	Path        string             `yaml:"-"`                     // where am I from ?
+	Columns     map[string]*Column `yaml:"-"`                     // column map
+	ColumnNames []string           `yaml:"-"`                     // column names in origin orders
+	LabelNames  []string           `yaml:"-"`                     // column (name) that used as label, sequences matters
+	MetricNames []string           `yaml:"-"`                     // column (name) that used as metric
+	Public      bool               `yaml:"public,omitempty"`      // autoDiscover下公用指标,只采集一次
+	Requires    []*GUCRequirement  `yaml:"requires,omitempty"`    // GUCs that must be on for this query to return data
+	DBNameLabel string             `yaml:"dbNameLabel,omitempty"` // column holding the database name of a row, detected from the label columns if empty
 }
 
 type Query struct {
@@ -178,9 +179,6 @@ func (q *QueryInstance) Check() error {
 		switch column.Usage {
 		case LABEL:
 			labelColumns = append(labelColumns, column.Name)
-			if strings.EqualFold(column.Name, "datname") {
-				q.DBNameLabel = column.Name
-			}
 			column.DisCard = true
 		case DISCARD:
 			column.DisCard = true
@@ -200,9 +198,26 @@ func (q *QueryInstance) Check() error {
 		columns[column.Name] = column
 	}
 	q.Columns, q.ColumnNames, q.LabelNames, q.MetricNames = columns, allColumns, labelColumns, metricColumns
+	if q.DBNameLabel == "" {
+		q.DBNameLabel = detectDBNameLabel(labelColumns)
+	}
 	return nil
 }
 
+// dbNameLabels label column names recognised as holding the database name of a row
+var dbNameLabels = []string{"datname", "database", "db_name"}
+
+func detectDBNameLabel(labelColumns []string) string {
+	for _, name := range dbNameLabels {
+		for _, column := range labelColumns {
+			if strings.EqualFold(column, name) {
+				return column
+			}
+		}
+	}
+	return ""
+}
+
 // GetQuerySQL Get query sql according to version
 func (q *QueryInstance) GetQuerySQL(ver semver.Version, isPrimary bool) *Query {
 	for _, query := range q.Queries {
@@ -224,19 +239,19 @@ func (q *QueryInstance) GetColumn(colName string, serverLabels prometheus.Labels
 			col.DisCard = true
 		case GAUGE:
 			col.PrometheusType = prometheus.GaugeValue
-			col.PrometheusDesc = prometheus.NewDesc(fmt.Sprintf("%s_%s", q.Name, col.Name), col.Desc, q.LabelNames, serverLabels)
+			col.PrometheusDesc = prometheus.NewDesc(fmt.Sprintf("%s_%s", q.Name, col.Name), col.Help(), q.LabelNames, serverLabels)
 		case COUNTER:
 			col.PrometheusType = prometheus.CounterValue
-			col.PrometheusDesc = prometheus.NewDesc(fmt.Sprintf("%s_%s", q.Name, col.Name), col.Desc, q.LabelNames, serverLabels)
+			col.PrometheusDesc = prometheus.NewDesc(fmt.Sprintf("%s_%s", q.Name, col.Name), col.Help(), q.LabelNames, serverLabels)
 		case HISTOGRAM:
 			col.PrometheusType = prometheus.UntypedValue
-			col.PrometheusDesc = prometheus.NewDesc(fmt.Sprintf("%s_%s", q.Name, col.Name), col.Desc, q.LabelNames, serverLabels)
+			col.PrometheusDesc = prometheus.NewDesc(fmt.Sprintf("%s_%s", q.Name, col.Name), col.Help(), q.LabelNames, serverLabels)
 		case MappedMETRIC:
 			col.PrometheusType = prometheus.GaugeValue
-			col.PrometheusDesc = prometheus.NewDesc(fmt.Sprintf("%s_%s", q.Name, col.Name), col.Desc, q.LabelNames, serverLabels)
+			col.PrometheusDesc = prometheus.NewDesc(fmt.Sprintf("%s_%s", q.Name, col.Name), col.Help(), q.LabelNames, serverLabels)
 		case DURATION:
 			col.PrometheusType = prometheus.GaugeValue
-			col.PrometheusDesc = prometheus.NewDesc(fmt.Sprintf("%s_%s_milliseconds", q.Name, col.Name), col.Desc, q.LabelNames, serverLabels)
+			col.PrometheusDesc = prometheus.NewDesc(fmt.Sprintf("%s_%s_milliseconds", q.Name, col.Name), col.Help(), q.LabelNames, serverLabels)
 		}
 
 		return col
@@ -315,6 +330,29 @@ func (q *QueryInstance) MetricList() (res []string) {
 	return
 }
 
+// MetricFamilies returns the names of the metric families exported by this query
+func (q *QueryInstance) MetricFamilies() []string {
+	res := make([]string, 0, len(q.MetricNames))
+	for _, metricName := range q.MetricNames {
+		if family, ok := q.metricFamily(q.Columns[metricName]); ok {
+			res = append(res, family)
+		}
+	}
+	return res
+}
+
+// metricFamily returns the name of the metric family exported by a metric column
+func (q *QueryInstance) metricFamily(column *Column) (string, bool) {
+	switch column.Usage {
+	case MappedMETRIC, HISTOGRAM:
+		return "", false
+	case DURATION:
+		return fmt.Sprintf("%s_%s_milliseconds", q.Name, column.Name), true
+	default:
+		return fmt.Sprintf("%s_%s", q.Name, column.Name), true
+	}
+}
+
 // LabelList returns a list of label column names
 func (q *QueryInstance) LabelList() []string {
 	labelNames := make([]string, len(q.LabelNames))
@@ -0,0 +1,73 @@
+package config
+
+import "sort"
+
+// Report the effective configuration exposed on /config
+type Report struct {
+	Databases DatabaseReport `json:"databases"`
+	Queries   []QueryReport  `json:"queries"`
+}
+
+// DatabaseReport the database filters and the resolved list of scraped databases
+type DatabaseReport struct {
+	AutoDiscovery bool     `json:"auto_discovery"`
+	Include       []string `json:"include,omitempty"`
+	Exclude       []string `json:"exclude,omitempty"`
+	Resolved      []string `json:"resolved"`
+}
+
+// QueryReport the effective state of a query and the metric families it lights up
+type QueryReport struct {
+	Name     string      `json:"name"`
+	Path     string      `json:"path"`
+	Status   string      `json:"status"`
+	Enabled  bool        `json:"enabled"`
+	Metrics  []string    `json:"metrics"`
+	Requires []GUCReport `json:"requires,omitempty"`
+}
+
+// GUCReport a GUC required by a query and its current setting on the server
+type GUCReport struct {
+	GUC       string `json:"guc"`
+	Setting   string `json:"setting"`
+	Satisfied bool   `json:"satisfied"`
+}
+
+// BuildReport collect the effective configuration of the loaded queries
+func BuildReport() *Report {
+	names := make([]string, 0, len(MetricMap))
+	for name := range MetricMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := &Report{
+		Databases: DatabaseReport{
+			AutoDiscovery: AutoDiscovery,
+			Include:       DBFilter.Include,
+			Exclude:       DBFilter.Exclude,
+			Resolved:      ResolvedDatabases(),
+		},
+		Queries: make([]QueryReport, 0, len(names)),
+	}
+	for _, name := range names {
+		q := MetricMap[name]
+		qr := QueryReport{
+			Name:    name,
+			Path:    q.Path,
+			Status:  q.Status,
+			Enabled: q.Status != statusDisable,
+			Metrics: q.MetricFamilies(),
+		}
+		for _, req := range q.Requires {
+			setting, probed := GUCSettings[req.Name]
+			satisfied := !probed || req.Satisfied(setting)
+			if !satisfied {
+				qr.Enabled = false
+			}
+			qr.Requires = append(qr.Requires, GUCReport{GUC: req.Name, Setting: setting, Satisfied: satisfied})
+		}
+		report.Queries = append(report.Queries, qr)
+	}
+	return report
+}
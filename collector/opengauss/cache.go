@@ -0,0 +1,128 @@
+package opengauss
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector/config"
+)
+
+const (
+	sourceLive  = "live"  // metrics queried from the database during this scrape
+	sourceCache = "cache" // metrics served from cache within their TTL
+	sourceStale = "stale" // the query failed, metrics served from an expired cache entry
+	sourceError = "error" // the query failed and no cache entry was usable, no data exposed
+)
+
+// maxStaleTTLs bounds how old, in TTLs, an expired cache entry served as a stale fallback may be.
+// Past that the query error is reported and no data is exposed.
+const maxStaleTTLs = 3
+
+var collectionSources = []string{sourceLive, sourceCache, sourceStale, sourceError}
+
+// CollectionSource where the metrics last exposed by a collector came from
+type CollectionSource struct {
+	Collector   string    `json:"collector"`
+	Source      string    `json:"source"`
+	CollectedAt time.Time `json:"collected_at"`
+	Age         string    `json:"age"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+type cacheEntry struct {
+	metrics   []prometheus.Metric
+	collected time.Time
+}
+
+var (
+	cacheMtx    sync.Mutex
+	metricCache = make(map[string]*cacheEntry)
+	lastSource  = make(map[string]*CollectionSource)
+
+	// runQuery query the metrics of a collector from the database, replaced in tests
+	runQuery = queryDatabases
+)
+
+// cachedMetric serve the metrics of queryInstance from cache while they are within TTL,
+// query the database otherwise and fall back to the expired cache entry if the query fails
+// and the entry is less than maxStaleTTLs TTLs old. Caching is opt-in with enableCache on the
+// query instance and uses its ttl, enableCache and ttl of the individual queries are not used.
+func cachedMetric(ctx context.Context, db *sql.DB, queryInstance *config.QueryInstance) ([]prometheus.Metric, string) {
+	name := queryInstance.Name
+	ttl := time.Duration(queryInstance.TTL * float64(time.Second))
+	useCache := queryInstance.IsEnableCache() && !config.DisableCache && ttl > 0
+
+	cacheMtx.Lock()
+	entry := metricCache[name]
+	cacheMtx.Unlock()
+	if useCache && entry != nil && time.Since(entry.collected) < ttl {
+		setSource(name, sourceCache, entry.collected, nil)
+		return entry.metrics, sourceCache
+	}
+
+	metrics, err := runQuery(ctx, db, queryInstance)
+	if err != nil && useCache && entry != nil && time.Since(entry.collected) < maxStaleTTLs*ttl {
+		setSource(name, sourceStale, entry.collected, err)
+		return entry.metrics, sourceStale
+	}
+	now := time.Now()
+	if err != nil {
+		setSource(name, sourceError, now, err)
+		return nil, sourceError
+	}
+	if useCache {
+		cacheMtx.Lock()
+		// clip so appending to the returned slice never writes into the cached one
+		metricCache[name] = &cacheEntry{metrics: metrics[:len(metrics):len(metrics)], collected: now}
+		cacheMtx.Unlock()
+	}
+	setSource(name, sourceLive, now, nil)
+	return metrics, sourceLive
+}
+
+func setSource(name, source string, collected time.Time, err error) {
+	s := &CollectionSource{Collector: name, Source: source, CollectedAt: collected}
+	if err != nil {
+		s.LastError = err.Error()
+	}
+	cacheMtx.Lock()
+	lastSource[name] = s
+	cacheMtx.Unlock()
+}
+
+// sourceMetrics export which source the metrics of queryInstance came from, one series per source
+func sourceMetrics(queryInstance *config.QueryInstance, source string) []prometheus.Metric {
+	desc := prometheus.NewDesc(prometheus.BuildFQName(config.Namespace, "exporter", "collection_source"),
+		"where the last exposed data of a collector came from: live query, cache, stale fallback, or error when no data is exposed",
+		[]string{"collector", "source"},
+		prometheus.Labels{"server": fmt.Sprintf("%s:%d", config.MonitDB.Address, config.MonitDB.Port)})
+	metrics := make([]prometheus.Metric, 0, len(collectionSources))
+	for _, s := range collectionSources {
+		var value float64
+		if s == source {
+			value = 1
+		}
+		metrics = append(metrics, prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, queryInstance.Name, s))
+	}
+	return metrics
+}
+
+// CollectionSources return where the metrics last exposed by each collector came from
+func CollectionSources() []CollectionSource {
+	cacheMtx.Lock()
+	defer cacheMtx.Unlock()
+	now := time.Now()
+	res := make([]CollectionSource, 0, len(lastSource))
+	for _, s := range lastSource {
+		source := *s
+		source.Age = now.Sub(s.CollectedAt).Round(time.Millisecond).String()
+		res = append(res, source)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Collector < res[j].Collector })
+	return res
+}
@@ -0,0 +1,91 @@
+package opengauss
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector/config"
+)
+
+func TestCachedMetric(t *testing.T) {
+	desc := prometheus.NewDesc("test_cache_value", "test", nil, nil)
+	liveMetric := prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1)
+	cachedMetricValue := prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 2)
+	queryErr := errors.New("connection refused")
+
+	tests := []struct {
+		name         string
+		disableCache bool
+		entryAge     time.Duration // age of the cache entry, no entry if 0
+		queryErr     error
+		wantSource   string
+		wantMetrics  []prometheus.Metric
+		wantCached   bool // whether the live result ends up in the cache
+	}{
+		{name: "no cache entry", queryErr: nil, wantSource: sourceLive, wantMetrics: []prometheus.Metric{liveMetric}, wantCached: true},
+		{name: "entry within ttl", entryAge: 30 * time.Second, wantSource: sourceCache, wantMetrics: []prometheus.Metric{cachedMetricValue}},
+		{name: "entry expired", entryAge: 90 * time.Second, wantSource: sourceLive, wantMetrics: []prometheus.Metric{liveMetric}, wantCached: true},
+		{name: "query failed, entry expired", entryAge: 90 * time.Second, queryErr: queryErr, wantSource: sourceStale, wantMetrics: []prometheus.Metric{cachedMetricValue}},
+		{name: "query failed, entry too old", entryAge: maxStaleTTLs * time.Minute, queryErr: queryErr, wantSource: sourceError},
+		{name: "query failed, no entry", queryErr: queryErr, wantSource: sourceError},
+		{name: "cache disabled", disableCache: true, entryAge: 30 * time.Second, wantSource: sourceLive, wantMetrics: []prometheus.Metric{liveMetric}},
+		{name: "cache disabled, query failed", disableCache: true, entryAge: 90 * time.Second, queryErr: queryErr, wantSource: sourceError},
+	}
+	defer func(orig func(context.Context, *sql.DB, *config.QueryInstance) ([]prometheus.Metric, error), disable bool) {
+		runQuery = orig
+		config.DisableCache = disable
+	}(runQuery, config.DisableCache)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queryInstance := &config.QueryInstance{Name: "test_cache", EnableCache: "enable", TTL: 60}
+			config.DisableCache = tt.disableCache
+			runQuery = func(context.Context, *sql.DB, *config.QueryInstance) ([]prometheus.Metric, error) {
+				if tt.queryErr != nil {
+					return nil, tt.queryErr
+				}
+				return []prometheus.Metric{liveMetric}, nil
+			}
+			cacheMtx.Lock()
+			delete(metricCache, queryInstance.Name)
+			if tt.entryAge > 0 {
+				metricCache[queryInstance.Name] = &cacheEntry{
+					metrics:   []prometheus.Metric{cachedMetricValue},
+					collected: time.Now().Add(-tt.entryAge),
+				}
+			}
+			cacheMtx.Unlock()
+
+			metrics, source := cachedMetric(context.Background(), nil, queryInstance)
+			if source != tt.wantSource {
+				t.Errorf("source = %s, want %s", source, tt.wantSource)
+			}
+			if len(metrics) != len(tt.wantMetrics) {
+				t.Fatalf("got %d metrics, want %d", len(metrics), len(tt.wantMetrics))
+			}
+			for i := range metrics {
+				if metrics[i] != tt.wantMetrics[i] {
+					t.Errorf("metric %d is not the expected one", i)
+				}
+			}
+
+			cacheMtx.Lock()
+			entry := metricCache[queryInstance.Name]
+			last := lastSource[queryInstance.Name]
+			cacheMtx.Unlock()
+			if tt.wantCached && (entry == nil || entry.metrics[0] != liveMetric) {
+				t.Errorf("live metrics not cached")
+			}
+			if last.Source != tt.wantSource {
+				t.Errorf("last source = %s, want %s", last.Source, tt.wantSource)
+			}
+			if tt.queryErr != nil && last.LastError != tt.queryErr.Error() {
+				t.Errorf("last error = %q, want %q", last.LastError, tt.queryErr.Error())
+			}
+		})
+	}
+}
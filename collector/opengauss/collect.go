@@ -14,19 +14,73 @@ import (
 )
 
 func getMetric(ctx context.Context, db *sql.DB, queryInstance *config.QueryInstance) []prometheus.Metric {
+	if guc, setting := queryInstance.UnmetRequirement(); guc != nil {
+		return []prometheus.Metric{disabledMetric(queryInstance, guc, setting)}
+	}
+	metrics, source := cachedMetric(ctx, db, queryInstance)
+	return append(metrics, sourceMetrics(queryInstance, source)...)
+}
+
+// queryDatabases run a non public query on every resolved database when auto-discovery is on,
+// on the target database otherwise. An error is returned only when every database failed
+func queryDatabases(ctx context.Context, db *sql.DB, queryInstance *config.QueryInstance) ([]prometheus.Metric, error) {
+	databases := config.ResolvedDatabases()
+	if queryInstance.Public || !config.AutoDiscovery || len(databases) == 0 {
+		return queryMetric(ctx, db, queryInstance)
+	}
+	metrics := make([]prometheus.Metric, 0)
+	var queryErr error
+	var succeeded int
+	for _, datname := range databases {
+		conn, err := config.DatabaseConnection(datname)
+		if err != nil {
+			utils.ThrottledError("db connect is failed", "query", queryInstance.Name, "database", datname, "err", err)
+			queryErr = err
+			continue
+		}
+		res, err := queryMetric(ctx, conn, queryInstance)
+		metrics = append(metrics, res...)
+		if err != nil {
+			queryErr = err
+			continue
+		}
+		succeeded++
+	}
+	if succeeded == 0 && queryErr != nil {
+		return metrics, queryErr
+	}
+	return metrics, nil
+}
+
+// queryMetric run the queries of queryInstance against the database,
+// an error is returned only when every query failed
+func queryMetric(ctx context.Context, db *sql.DB, queryInstance *config.QueryInstance) ([]prometheus.Metric, error) {
 	columnNames := make([]string, 0)
 	var list [][]interface{}
+	var queryErr error
+	var succeeded int
 
 	for _, query := range queryInstance.Queries {
 		if query.Status == "disable" {
 			continue
 		}
+		done := utils.TrackQuery(queryInstance.Name, query.SQL)
+		if err := injectFault(ctx, queryInstance.Name); err != nil {
+			done()
+			utils.ThrottledError("db Query is failed", "query", queryInstance.Name, "err", err)
+			queryErr = err
+			continue
+		}
 		rows, err := db.QueryContext(ctx, query.SQL)
 		if err != nil {
-			slog.Error("db Query is failed", slog.Any("err", err))
+			done()
+			utils.ThrottledError("db Query is failed", "query", queryInstance.Name, "err", err)
+			queryErr = err
 			continue
 		}
+		succeeded++
 		if rows == nil {
+			done()
 			slog.Warn("rows is empty")
 			continue
 		}
@@ -39,17 +93,20 @@ func getMetric(ctx context.Context, db *sql.DB, queryInstance *config.QueryInsta
 			}
 			err = rows.Scan(scanArgs...)
 			if err != nil {
-				slog.Error("errr ")
+				utils.ThrottledError("scan row failed", "query", queryInstance.Name, "err", err)
 				break
 			}
 			list = append(list, columnData)
 		}
+		done()
 	}
+	list = partialRows(queryInstance.Name, list)
 	// Make a lookup map for the column indices
 	var columnIdx = make(map[string]int, len(columnNames))
 	for i, n := range columnNames {
 		columnIdx[n] = i
 	}
+	list = filterDatabases(queryInstance, columnIdx, list)
 	metrics := make([]prometheus.Metric, 0)
 	for i := range list {
 		metric, errs := procRows(queryInstance, columnNames, columnIdx, list[i])
@@ -60,25 +117,45 @@ func getMetric(ctx context.Context, db *sql.DB, queryInstance *config.QueryInsta
 			metrics = append(metrics, metric...)
 		}
 	}
-	return metrics
+	if succeeded == 0 && queryErr != nil {
+		return metrics, queryErr
+	}
+	return metrics, nil
+}
+
+// filterDatabases drop the rows of databases excluded by --include-databases/--exclude-databases,
+// rows with no database name (e.g. physical replication slots) are kept
+func filterDatabases(queryInstance *config.QueryInstance, columnIdx map[string]int, list [][]interface{}) [][]interface{} {
+	if queryInstance.DBNameLabel == "" {
+		return list
+	}
+	idx, ok := columnIdx[queryInstance.DBNameLabel]
+	if !ok {
+		return list
+	}
+	filtered := list[:0]
+	for _, columnData := range list {
+		if dbName, _ := utils.DbToString(columnData[idx], true); dbName == "" || config.DBFilter.Allowed(dbName) {
+			filtered = append(filtered, columnData)
+		}
+	}
+	return filtered
 }
+
+// disabledMetric explain why a collector returns no data: a GUC it depends on is turned off
+func disabledMetric(queryInstance *config.QueryInstance, guc *config.GUCRequirement, setting string) prometheus.Metric {
+	desc := prometheus.NewDesc(prometheus.BuildFQName(config.Namespace, "exporter", "collector_disabled"),
+		"collector disabled because a GUC it depends on is turned off",
+		[]string{"collector", "guc", "setting"},
+		prometheus.Labels{"server": fmt.Sprintf("%s:%d", config.MonitDB.Address, config.MonitDB.Port)})
+	return prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, queryInstance.Name, guc.Name, setting)
+}
+
 func procRows(queryInstance *config.QueryInstance, columnNames []string, columnIdx map[string]int, columnData []interface{}) ([]prometheus.Metric, []error) {
 	// Get the label values for this row.
 	metrics := make([]prometheus.Metric, 0)
 	nonfatalErrors := []error{}
-	labels := make([]string, len(queryInstance.LabelNames))
-	var dbName string
-	dbNameLabel := queryInstance.DBNameLabel
-	if dbNameLabel != "" {
-		dbName, _ = utils.DbToString(columnData[columnIdx[dbNameLabel]], true)
-	}
-	for idx, label := range queryInstance.LabelNames {
-		v, err := decode(queryInstance, columnData[columnIdx[label]], label, dbName)
-		if err != nil {
-			slog.Error("decode error", slog.Any("err", err))
-		}
-		labels[idx] = v
-	}
+	labels := rowLabels(queryInstance, columnIdx, columnData)
 	// Loop over column names, and match to scan data. Unknown columns
 	// will be filled with an untyped metric number *if* they can be
 	// converted to float64s. NULLs are allowed and treated as NaN.
@@ -87,7 +164,7 @@ func procRows(queryInstance *config.QueryInstance, columnNames []string, columnI
 		col := queryInstance.GetColumn(columnName, prometheus.Labels{"server": fmt.Sprintf("%s:%d", config.MonitDB.Address, config.MonitDB.Port)})
 		metric, err := newMetric(queryInstance, col, columnName, columnData[idx], labels)
 		if err != nil {
-			slog.Error("newMetric", slog.Any("err", err))
+			utils.ThrottledError("newMetric", "query", queryInstance.Name, "err", err)
 			nonfatalErrors = append(nonfatalErrors, err)
 			continue
 		}
@@ -98,6 +175,23 @@ func procRows(queryInstance *config.QueryInstance, columnNames []string, columnI
 	return metrics, nonfatalErrors
 }
 
+// rowLabels get the label values of a row
+func rowLabels(queryInstance *config.QueryInstance, columnIdx map[string]int, columnData []interface{}) []string {
+	labels := make([]string, len(queryInstance.LabelNames))
+	var dbName string
+	if idx, ok := columnIdx[queryInstance.DBNameLabel]; ok {
+		dbName, _ = utils.DbToString(columnData[idx], true)
+	}
+	for idx, label := range queryInstance.LabelNames {
+		v, err := decode(queryInstance, columnData[columnIdx[label]], label, dbName)
+		if err != nil {
+			utils.ThrottledError("decode error", "query", queryInstance.Name, "label", label, "err", err)
+		}
+		labels[idx] = v
+	}
+	return labels
+}
+
 func newMetric(queryInstance *config.QueryInstance, col *config.Column, columnName string, colValue interface{},
 	labels []string) (metric prometheus.Metric, err error) {
 	var (
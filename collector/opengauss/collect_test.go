@@ -0,0 +1,34 @@
+package opengauss
+
+import (
+	"testing"
+
+	"github.com/prometheus/node_exporter/collector/config"
+)
+
+func TestFilterDatabases(t *testing.T) {
+	defer func(f *config.DatabaseFilter) { config.DBFilter = f }(config.DBFilter)
+	f, err := config.NewDatabaseFilter("app_.*", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.DBFilter = f
+
+	queryInstance := &config.QueryInstance{Name: "test_filter", DBNameLabel: "db_name"}
+	columnIdx := map[string]int{"db_name": 0, "value": 1}
+	list := [][]interface{}{
+		{"app_order", int64(1)},
+		{"postgres", int64(2)},
+		{nil, int64(3)},
+		{[]byte("app_user"), int64(4)},
+	}
+	filtered := filterDatabases(queryInstance, columnIdx, list)
+	if len(filtered) != 3 {
+		t.Fatalf("want 3 rows, got %d", len(filtered))
+	}
+	for i, want := range []int64{1, 3, 4} {
+		if got := filtered[i][1].(int64); got != want {
+			t.Errorf("row %d: want value %d, got %d", i, want, got)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+//go:build faultinject
+// +build faultinject
+
+package opengauss
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Fault a failure injected into the collection of a query, for integration tests and game-days
+type Fault struct {
+	Query string        `json:"query"`
+	Delay time.Duration `json:"delay"`           // sleep before running the query
+	Error string        `json:"error,omitempty"` // fail the query with this error
+	Rows  int           `json:"rows"`            // keep only the first rows, -1 keeps all
+}
+
+// MarshalJSON encode Delay as a duration string, e.g. "1.5s", the same format the delay parameter takes
+func (f *Fault) MarshalJSON() ([]byte, error) {
+	type fault Fault
+	return json.Marshal(struct {
+		*fault
+		Delay string `json:"delay"`
+	}{(*fault)(f), f.Delay.String()})
+}
+
+var (
+	faultMtx sync.Mutex
+	faults   = make(map[string]*Fault)
+)
+
+// SetFault inject fault into the collection of fault.Query
+func SetFault(fault *Fault) {
+	faultMtx.Lock()
+	defer faultMtx.Unlock()
+	faults[fault.Query] = fault
+}
+
+// ClearFault remove the fault injected into query, every fault if query is empty
+func ClearFault(query string) {
+	faultMtx.Lock()
+	defer faultMtx.Unlock()
+	if query == "" {
+		faults = make(map[string]*Fault)
+		return
+	}
+	delete(faults, query)
+}
+
+func getFault(query string) *Fault {
+	faultMtx.Lock()
+	defer faultMtx.Unlock()
+	return faults[query]
+}
+
+// injectFault delay and/or fail the query according to the fault injected into it
+func injectFault(ctx context.Context, query string) error {
+	fault := getFault(query)
+	if fault == nil {
+		return nil
+	}
+	if fault.Delay > 0 {
+		select {
+		case <-time.After(fault.Delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if fault.Error != "" {
+		return errors.New(fault.Error)
+	}
+	return nil
+}
+
+// partialRows truncate the rows of the query according to the fault injected into it
+func partialRows(query string, list [][]interface{}) [][]interface{} {
+	fault := getFault(query)
+	if fault == nil || fault.Rows < 0 || fault.Rows >= len(list) {
+		return list
+	}
+	return list[:fault.Rows]
+}
+
+// RegisterFaultHandlers expose /admin/faults to manage injected faults:
+// GET lists them, POST ?query=&delay=&error=&rows= sets one, DELETE ?query= clears them
+func RegisterFaultHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/admin/faults", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost, http.MethodPut:
+			fault, err := parseFault(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			SetFault(fault)
+		case http.MethodDelete:
+			ClearFault(r.URL.Query().Get("query"))
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		faultMtx.Lock()
+		list := make([]*Fault, 0, len(faults))
+		for _, fault := range faults {
+			list = append(list, fault)
+		}
+		faultMtx.Unlock()
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		_ = json.NewEncoder(w).Encode(list)
+	})
+}
+
+func parseFault(r *http.Request) (*Fault, error) {
+	params := r.URL.Query()
+	fault := &Fault{Query: params.Get("query"), Error: params.Get("error"), Rows: -1}
+	if fault.Query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if s := params.Get("delay"); s != "" {
+		delay, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid delay: %w", err)
+		}
+		fault.Delay = delay
+	}
+	if s := params.Get("rows"); s != "" {
+		rows, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rows: %w", err)
+		}
+		fault.Rows = rows
+	}
+	return fault, nil
+}
@@ -0,0 +1,22 @@
+//go:build !faultinject
+// +build !faultinject
+
+package opengauss
+
+import (
+	"context"
+	"net/http"
+)
+
+// injectFault is a no-op unless built with the faultinject tag
+func injectFault(ctx context.Context, query string) error {
+	return nil
+}
+
+// partialRows is a no-op unless built with the faultinject tag
+func partialRows(query string, list [][]interface{}) [][]interface{} {
+	return list
+}
+
+// RegisterFaultHandlers is a no-op unless built with the faultinject tag
+func RegisterFaultHandlers(mux *http.ServeMux) {}
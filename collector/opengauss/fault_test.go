@@ -0,0 +1,59 @@
+//go:build faultinject
+// +build faultinject
+
+package opengauss
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFaultInjection(t *testing.T) {
+	defer ClearFault("")
+	mux := http.NewServeMux()
+	RegisterFaultHandlers(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/faults?query=pg_lock&delay=10ms&error=boom&rows=1", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var listed []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0]["delay"] != "10ms" {
+		t.Errorf("want delay listed as 10ms, got %s", rec.Body)
+	}
+
+	begin := time.Now()
+	if err := injectFault(context.Background(), "pg_lock"); err == nil || err.Error() != "boom" {
+		t.Errorf("want injected error boom, got %v", err)
+	}
+	if time.Since(begin) < 10*time.Millisecond {
+		t.Errorf("want injected delay")
+	}
+	if got := partialRows("pg_lock", [][]interface{}{{1}, {2}, {3}}); len(got) != 1 {
+		t.Errorf("want 1 row, got %d", len(got))
+	}
+	if err := injectFault(context.Background(), "pg_database"); err != nil {
+		t.Errorf("want no fault on other queries, got %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/admin/faults?query=pg_lock", nil))
+	if err := injectFault(context.Background(), "pg_lock"); err != nil {
+		t.Errorf("want fault cleared, got %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/faults?delay=1s", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("want 400 without query, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,47 @@
+package opengauss
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector"
+	"github.com/prometheus/node_exporter/collector/config"
+)
+
+type gaussStatementLatencyCollector struct {
+	logger log.Logger
+	db     *sql.DB
+}
+
+func (p *gaussStatementLatencyCollector) name() string {
+	return "gauss_statement_latency"
+}
+
+func (p *gaussStatementLatencyCollector) Update(ch chan<- prometheus.Metric) error {
+	queryInstance, ok := config.MetricMap[p.name()]
+	if !ok {
+		return fmt.Errorf("can not find gauss_statement_latency from MetricMap")
+	}
+
+	if err := queryInstance.Check(); err != nil {
+		return err
+	}
+	metrics := getMetric(context.TODO(), p.db, queryInstance)
+	for _, metric := range metrics {
+		ch <- metric
+	}
+	return nil
+}
+
+func init() {
+	collector.RegisterCollector("gauss_statement_latency", collector.DefaultEnabled, NewgaussStatementLatencyCollector)
+}
+
+func NewgaussStatementLatencyCollector(logger log.Logger) (collector.Collector, error) {
+	return &gaussStatementLatencyCollector{
+		db:     config.GetDBConnection(config.MonitDB.Address, config.MonitDB.Port),
+		logger: logger,
+	}, nil
+}
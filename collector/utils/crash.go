@@ -0,0 +1,165 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	crashFilePrefix = "og_exporter_crash_"
+	// maxCrashFiles bounds the number of crash files kept in the crash dir,
+	// so a crash-looping exporter does not fill up the disk.
+	maxCrashFiles = 10
+)
+
+var (
+	crashMtx      sync.Mutex
+	crashDir      string
+	crashSnapshot func() string
+
+	inFlightMtx   sync.Mutex
+	inFlightSeq   uint64
+	inFlightQuery = make(map[uint64]InFlightQuery)
+)
+
+// InFlightQuery is a query currently executed against the database.
+type InFlightQuery struct {
+	Name    string    `json:"name"`
+	SQL     string    `json:"sql"`
+	Started time.Time `json:"started"`
+}
+
+// SetCrashDir set the directory crash files are written to, os.TempDir() is used if empty
+func SetCrashDir(dir string) {
+	crashMtx.Lock()
+	defer crashMtx.Unlock()
+	crashDir = dir
+}
+
+// SetCrashSnapshot set the function used to dump the current config into crash files
+func SetCrashSnapshot(fn func() string) {
+	crashMtx.Lock()
+	defer crashMtx.Unlock()
+	crashSnapshot = fn
+}
+
+// TrackQuery mark a query as in-flight, the returned func must be called once the query is done
+func TrackQuery(name, sql string) func() {
+	inFlightMtx.Lock()
+	inFlightSeq++
+	id := inFlightSeq
+	inFlightQuery[id] = InFlightQuery{Name: name, SQL: sql, Started: time.Now()}
+	inFlightMtx.Unlock()
+	return func() {
+		inFlightMtx.Lock()
+		delete(inFlightQuery, id)
+		inFlightMtx.Unlock()
+	}
+}
+
+// InFlightQueries return the queries currently executed, oldest first
+func InFlightQueries() []InFlightQuery {
+	inFlightMtx.Lock()
+	res := make([]InFlightQuery, 0, len(inFlightQuery))
+	for _, q := range inFlightQuery {
+		res = append(res, q)
+	}
+	inFlightMtx.Unlock()
+	sort.Slice(res, func(i, j int) bool { return res[i].Started.Before(res[j].Started) })
+	return res
+}
+
+// HandlePanic write a crash file and exit if the calling goroutine is panicking.
+// It must be deferred directly: defer utils.HandlePanic()
+func HandlePanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+	path, err := WriteCrashFile(r, stack)
+	if err != nil {
+		slog.Error("fail to write crash file", slog.Any("panic", r), slog.Any("error", err))
+	} else {
+		slog.Error("exporter panic, crash file written", slog.Any("panic", r), slog.String("path", path))
+	}
+	fmt.Fprintf(os.Stderr, "panic: %v\n\n%s", r, stack)
+	os.Exit(2)
+}
+
+// CrashDir return the directory crash files and diagnostic dumps are written to
+func CrashDir() string {
+	crashMtx.Lock()
+	defer crashMtx.Unlock()
+	if crashDir == "" {
+		return os.TempDir()
+	}
+	return crashDir
+}
+
+// WriteCrashFile dump the panic value, config snapshot, in-flight queries and stack into the crash dir
+func WriteCrashFile(r interface{}, stack []byte) (string, error) {
+	dir := CrashDir()
+	crashMtx.Lock()
+	defer crashMtx.Unlock()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create crash dir %s: %w", dir, err)
+	}
+
+	now := time.Now()
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "time: %s\npid: %d\npanic: %v\n", now.Format(time.RFC3339Nano), os.Getpid(), r)
+	buf.WriteString("\n# config snapshot\n")
+	if crashSnapshot != nil {
+		buf.WriteString(crashSnapshot())
+	}
+	buf.WriteString("\n# in-flight queries\n")
+	for _, q := range InFlightQueries() {
+		fmt.Fprintf(buf, "%s (running %s): %s\n", q.Name, now.Sub(q.Started), strings.TrimSpace(q.SQL))
+	}
+	buf.WriteString("\n# stack trace\n")
+	buf.Write(stack)
+
+	path := filepath.Join(dir, fmt.Sprintf("%s%s_%d.log", crashFilePrefix, now.Format("20060102T150405.000"), os.Getpid()))
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return "", err
+	}
+	pruneCrashFiles(dir)
+	return path, nil
+}
+
+// pruneCrashFiles keep only the newest maxCrashFiles crash files in dir
+func pruneCrashFiles(dir string) {
+	files, err := filepath.Glob(filepath.Join(dir, crashFilePrefix+"*.log"))
+	if err != nil || len(files) <= maxCrashFiles {
+		return
+	}
+	// file names start with a sortable timestamp
+	sort.Strings(files)
+	for _, f := range files[:len(files)-maxCrashFiles] {
+		if err := os.Remove(f); err != nil {
+			slog.Warn("fail to remove old crash file", slog.String("path", f), slog.Any("error", err))
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteCrashFile(t *testing.T) {
+	dir := t.TempDir()
+	SetCrashDir(dir)
+	SetCrashSnapshot(func() string { return "queries:\n  pg_lock: status=enable\n" })
+	defer SetCrashDir("")
+	defer SetCrashSnapshot(nil)
+
+	done := TrackQuery("pg_lock", "SELECT * FROM pg_locks")
+	path, err := WriteCrashFile("boom", []byte("goroutine 1 [running]:\n"))
+	done()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"panic: boom", "pg_lock: status=enable", "SELECT * FROM pg_locks", "goroutine 1 [running]"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("crash file missing %q:\n%s", want, content)
+		}
+	}
+	if n := len(InFlightQueries()); n != 0 {
+		t.Errorf("want no in-flight queries after done, got %d", n)
+	}
+}
+
+func TestPruneCrashFiles(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < maxCrashFiles+3; i++ {
+		name := filepath.Join(dir, crashFilePrefix+strings.Repeat("a", i+1)+".log")
+		if err := os.WriteFile(name, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pruneCrashFiles(dir)
+	files, _ := filepath.Glob(filepath.Join(dir, crashFilePrefix+"*.log"))
+	if len(files) != maxCrashFiles {
+		t.Fatalf("want %d crash files, got %d", maxCrashFiles, len(files))
+	}
+	if _, err := os.Stat(filepath.Join(dir, crashFilePrefix+"a.log")); !os.IsNotExist(err) {
+		t.Errorf("oldest crash file should be removed")
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultErrorSummaryInterval how often repeated errors are summarized by default
+const DefaultErrorSummaryInterval = 5 * time.Minute
+
+// errorLog is the throttled logger used by ThrottledError
+var errorLog = NewThrottledLogger(DefaultErrorSummaryInterval)
+
+// ThrottledLogger dedupe identical errors: the first occurrence is logged right away,
+// repeats are counted and summarized once per interval.
+type ThrottledLogger struct {
+	mtx      sync.Mutex
+	interval time.Duration
+	entries  map[string]*throttledEntry
+	logger   *slog.Logger
+	now      func() time.Time
+	sweeper  sync.Once
+}
+
+type throttledEntry struct {
+	msg         string
+	args        []any
+	repeats     int
+	windowStart time.Time
+}
+
+// NewThrottledLogger create a ThrottledLogger summarizing repeated errors every interval
+func NewThrottledLogger(interval time.Duration) *ThrottledLogger {
+	return &ThrottledLogger{
+		interval: interval,
+		entries:  make(map[string]*throttledEntry),
+		now:      time.Now,
+	}
+}
+
+// SetErrorSummaryInterval set how often ThrottledError summarizes repeated errors, 0 disables throttling
+func SetErrorSummaryInterval(interval time.Duration) {
+	errorLog.mtx.Lock()
+	defer errorLog.mtx.Unlock()
+	errorLog.interval = interval
+}
+
+// ThrottledError log an error through the shared throttled logger, args are slog key-value pairs
+func ThrottledError(msg string, args ...any) {
+	errorLog.startSweeper()
+	errorLog.Error(msg, args...)
+}
+
+// Error log msg if it is the first occurrence in the current interval, count it otherwise
+func (l *ThrottledLogger) Error(msg string, args ...any) {
+	key := msg + "\xff" + fmt.Sprint(args...)
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.interval <= 0 {
+		l.log().Error(msg, args...)
+		return
+	}
+	now := l.now()
+	entry, ok := l.entries[key]
+	if !ok {
+		l.entries[key] = &throttledEntry{msg: msg, args: args, windowStart: now}
+		l.log().Error(msg, args...)
+		return
+	}
+	entry.repeats++
+	if now.Sub(entry.windowStart) >= l.interval {
+		l.summarize(entry, now)
+	}
+}
+
+// Flush summarize the errors repeated during an elapsed interval and forget the ones not seen since
+func (l *ThrottledLogger) Flush() {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	now := l.now()
+	for key, entry := range l.entries {
+		if now.Sub(entry.windowStart) < l.interval {
+			continue
+		}
+		if entry.repeats == 0 {
+			delete(l.entries, key)
+			continue
+		}
+		l.summarize(entry, now)
+	}
+}
+
+func (l *ThrottledLogger) summarize(entry *throttledEntry, now time.Time) {
+	args := append([]any{}, entry.args...)
+	args = append(args, "repeated", entry.repeats, "interval", now.Sub(entry.windowStart).Round(time.Second).String())
+	l.log().Error(entry.msg, args...)
+	entry.repeats = 0
+	entry.windowStart = now
+}
+
+func (l *ThrottledLogger) log() *slog.Logger {
+	if l.logger != nil {
+		return l.logger
+	}
+	return slog.Default()
+}
+
+// startSweeper flush the logger periodically so repeats are summarized even if the error stops
+func (l *ThrottledLogger) startSweeper() {
+	l.sweeper.Do(func() {
+		go func() {
+			for {
+				l.mtx.Lock()
+				interval := l.interval
+				l.mtx.Unlock()
+				if interval <= 0 {
+					interval = DefaultErrorSummaryInterval
+				}
+				time.Sleep(interval)
+				l.Flush()
+			}
+		}()
+	})
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThrottledLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	now := time.Unix(0, 0)
+	l := NewThrottledLogger(time.Minute)
+	l.logger = slog.New(slog.NewTextHandler(buf, nil))
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 5; i++ {
+		l.Error("Could not parse []byte", "error", "invalid syntax")
+	}
+	l.Error("db Query is failed", "err", "timeout")
+	if got := strings.Count(buf.String(), "\n"); got != 2 {
+		t.Fatalf("want first occurrences logged only, got %d lines:\n%s", got, buf)
+	}
+
+	buf.Reset()
+	now = now.Add(time.Minute)
+	l.Flush()
+	if got := buf.String(); strings.Count(got, "\n") != 1 || !strings.Contains(got, "repeated=4") {
+		t.Fatalf("want one summary with 4 repeats, got:\n%s", got)
+	}
+
+	// entries without repeats are forgotten after an interval and logged again
+	buf.Reset()
+	now = now.Add(time.Minute)
+	l.Flush()
+	l.Error("db Query is failed", "err", "timeout")
+	if got := buf.String(); strings.Count(got, "\n") != 1 || strings.Contains(got, "repeated") {
+		t.Fatalf("want error logged again as first occurrence, got:\n%s", got)
+	}
+}
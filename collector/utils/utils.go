@@ -177,14 +177,14 @@ func DbToFloat64(t interface{}) (float64, bool) {
 		strV := string(v)
 		result, err := strconv.ParseFloat(strV, 64)
 		if err != nil {
-			slog.Error("Could not parse []byte:", slog.Any("error", err))
+			ThrottledError("Could not parse []byte", "error", err)
 			return math.NaN(), false
 		}
 		return result, true
 	case string:
 		result, err := strconv.ParseFloat(v, 64)
 		if err != nil {
-			slog.Error("Could not parse string:", slog.Any("error", err))
+			ThrottledError("Could not parse string", "error", err)
 			return math.NaN(), false
 		}
 		return result, true
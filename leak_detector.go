@@ -0,0 +1,170 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector/utils"
+	"golang.org/x/exp/slog"
+)
+
+// leakSuspectChecks is the number of consecutive checks a leak condition must hold
+// before it is reported, so a slow scrape is not mistaken for a leak.
+const leakSuspectChecks = 3
+
+// leakDetector compares goroutine counts and sql.DB stats across scrapes. The
+// expected goroutine count is the lowest count seen while no query was running;
+// connections still in use while no query is running were not released, e.g.
+// rows never closed.
+type leakDetector struct {
+	db            *sql.DB
+	threshold     int
+	dumpGoroutine bool
+
+	expected       int
+	goroutineCheck int
+	connCheck      int
+
+	goroutines          prometheus.Gauge
+	goroutinesExpected  prometheus.Gauge
+	goroutineSuspected  prometheus.Gauge
+	connectionsOpen     prometheus.Gauge
+	connectionsInUse    prometheus.Gauge
+	connectionSuspected prometheus.Gauge
+}
+
+func newLeakDetector(db *sql.DB, namespace string, threshold int, dumpGoroutine bool) *leakDetector {
+	gauge := func(name, help string) prometheus.Gauge {
+		return prometheus.NewGauge(prometheus.GaugeOpts{Namespace: namespace, Subsystem: "exporter", Name: name, Help: help})
+	}
+	return &leakDetector{
+		db:            db,
+		threshold:     threshold,
+		dumpGoroutine: dumpGoroutine,
+
+		goroutines:          gauge("goroutines", "Number of goroutines of the exporter at the last leak check."),
+		goroutinesExpected:  gauge("goroutines_expected", "Lowest number of goroutines seen while no query was running."),
+		goroutineSuspected:  gauge("goroutine_leak_suspected", "Whether goroutines kept growing above the expected count."),
+		connectionsOpen:     gauge("db_connections_open", "Number of open connections to the database at the last leak check."),
+		connectionsInUse:    gauge("db_connections_in_use", "Number of connections in use at the last leak check."),
+		connectionSuspected: gauge("db_connection_leak_suspected", "Whether connections stayed in use while no query was running."),
+	}
+}
+
+// Describe implements the prometheus.Collector interface.
+func (d *leakDetector) Describe(ch chan<- *prometheus.Desc) {
+	for _, g := range d.gauges() {
+		g.Describe(ch)
+	}
+}
+
+// Collect implements the prometheus.Collector interface.
+func (d *leakDetector) Collect(ch chan<- prometheus.Metric) {
+	for _, g := range d.gauges() {
+		g.Collect(ch)
+	}
+}
+
+func (d *leakDetector) gauges() []prometheus.Gauge {
+	return []prometheus.Gauge{d.goroutines, d.goroutinesExpected, d.goroutineSuspected,
+		d.connectionsOpen, d.connectionsInUse, d.connectionSuspected}
+}
+
+// run check for leaks every interval, it never returns
+func (d *leakDetector) run(interval time.Duration) {
+	defer utils.HandlePanic()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		var stats sql.DBStats
+		if d.db != nil {
+			stats = d.db.Stats()
+		}
+		d.check(runtime.NumGoroutine(), len(utils.InFlightQueries()), stats)
+	}
+}
+
+// check update the gauges from a sample, only samples taken while no query is
+// running are compared, as scrapes legitimately hold goroutines and connections
+func (d *leakDetector) check(goroutines, inFlight int, stats sql.DBStats) {
+	d.goroutines.Set(float64(goroutines))
+	d.connectionsOpen.Set(float64(stats.OpenConnections))
+	d.connectionsInUse.Set(float64(stats.InUse))
+	if inFlight > 0 {
+		return
+	}
+
+	if d.expected == 0 || goroutines < d.expected {
+		d.expected = goroutines
+	}
+	d.goroutinesExpected.Set(float64(d.expected))
+	if goroutines > d.expected+d.threshold {
+		d.goroutineCheck++
+	} else {
+		d.goroutineCheck = 0
+	}
+	if d.goroutineCheck == leakSuspectChecks {
+		slog.Warn("goroutine leak suspected", slog.Int("goroutines", goroutines), slog.Int("expected", d.expected))
+		if d.dumpGoroutine {
+			d.dump()
+		}
+	}
+	d.goroutineSuspected.Set(boolToFloat(d.goroutineCheck >= leakSuspectChecks))
+
+	if stats.InUse > 0 {
+		d.connCheck++
+	} else {
+		d.connCheck = 0
+	}
+	if d.connCheck == leakSuspectChecks {
+		slog.Warn("connection leak suspected", slog.Int("in_use", stats.InUse), slog.Int("open", stats.OpenConnections))
+	}
+	d.connectionSuspected.Set(boolToFloat(d.connCheck >= leakSuspectChecks))
+}
+
+// dump write the stacks of all goroutines into the crash dir
+func (d *leakDetector) dump() {
+	dir := utils.CrashDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		slog.Error("fail to dump goroutines", slog.Any("error", err))
+		return
+	}
+	path := filepath.Join(dir, fmt.Sprintf("og_exporter_goroutines_%s.txt", time.Now().Format("20060102T150405")))
+	f, err := os.Create(path)
+	if err != nil {
+		slog.Error("fail to dump goroutines", slog.Any("error", err))
+		return
+	}
+	defer f.Close()
+	if err := pprof.Lookup("goroutine").WriteTo(f, 2); err != nil {
+		slog.Error("fail to dump goroutines", slog.Any("error", err))
+		return
+	}
+	slog.Warn("goroutines dumped", slog.String("path", path))
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,52 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLeakDetector(t *testing.T) {
+	d := newLeakDetector(nil, "pg", 10, false)
+
+	d.check(20, 0, sql.DBStats{})
+	// samples taken while queries run are not compared
+	for i := 0; i < leakSuspectChecks; i++ {
+		d.check(100, 2, sql.DBStats{InUse: 2})
+	}
+	if got := testutil.ToFloat64(d.goroutineSuspected); got != 0 {
+		t.Errorf("want no goroutine leak suspected during scrapes, got %v", got)
+	}
+
+	for i := 0; i < leakSuspectChecks; i++ {
+		d.check(40, 0, sql.DBStats{InUse: 1, OpenConnections: 1})
+	}
+	if got := testutil.ToFloat64(d.goroutinesExpected); got != 20 {
+		t.Errorf("want 20 expected goroutines, got %v", got)
+	}
+	if got := testutil.ToFloat64(d.goroutineSuspected); got != 1 {
+		t.Errorf("want goroutine leak suspected, got %v", got)
+	}
+	if got := testutil.ToFloat64(d.connectionSuspected); got != 1 {
+		t.Errorf("want connection leak suspected, got %v", got)
+	}
+
+	d.check(25, 0, sql.DBStats{OpenConnections: 1})
+	if got := testutil.ToFloat64(d.goroutineSuspected) + testutil.ToFloat64(d.connectionSuspected); got != 0 {
+		t.Errorf("want suspicion cleared, got %v", got)
+	}
+}